@@ -0,0 +1,173 @@
+package gps
+
+// This file defines the AST produced by Parse. Every node records a
+// byte Offset so callers can map a node back to the original source
+// (e.g. for error messages or source rewriting). For most nodes it is
+// the offset of the node's first token; an operator node (BinaryExpr,
+// BetweenExpr, LikeExpr, IsNullExpr, InExpr) records the offset of its
+// operator token instead, and a SelectItem or OrderItem takes its
+// expression's Pos.
+
+// Expr is implemented by every expression node.
+type Expr interface {
+	Pos() int
+}
+
+// SelectStmt is the root node produced by Parse.
+type SelectStmt struct {
+	Distinct bool
+	Columns  []*SelectItem
+	From     *TableRef
+	Joins    []*JoinClause
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+	OrderBy  []*OrderItem
+	Limit    *LimitClause
+	Union    *UnionClause
+	Offset   int
+}
+
+func (s *SelectStmt) Pos() int { return s.Offset }
+
+// SelectItem is a single projected column, e.g. `a.b AS c` or `*`.
+type SelectItem struct {
+	Star   bool
+	Expr   Expr
+	Alias  string
+	Offset int
+}
+
+// TableRef names a table, optionally aliased: `orders AS o`.
+type TableRef struct {
+	Name   string
+	Alias  string
+	Offset int
+}
+
+// JoinClause is one `[LEFT|RIGHT|INNER|OUTER] JOIN table ON cond` clause.
+// Kind is "" for a plain JOIN.
+type JoinClause struct {
+	Kind   string
+	Table  *TableRef
+	On     Expr
+	Offset int
+}
+
+// OrderItem is one `expr [ASC|DESC]` entry in an ORDER BY clause.
+type OrderItem struct {
+	Expr   Expr
+	Desc   bool
+	Offset int
+}
+
+// LimitClause is a `LIMIT n` clause.
+type LimitClause struct {
+	Count  int
+	Offset int
+}
+
+// UnionClause chains a second SELECT onto the statement that precedes it.
+type UnionClause struct {
+	All    bool
+	Select *SelectStmt
+	Offset int
+}
+
+// ColumnRef is a (possibly qualified) column reference: `b`, `a.b`, or
+// `schema.table.column`. Qualifiers holds every dot-separated part
+// before Name, outermost first, and is nil for an unqualified column.
+type ColumnRef struct {
+	Qualifiers []string
+	Name       string
+	Offset     int
+}
+
+func (c *ColumnRef) Pos() int { return c.Offset }
+
+// LiteralExpr is a scalar literal: a number, string, boolean or NULL.
+type LiteralExpr struct {
+	Type   TokenType
+	Value  string
+	Offset int
+}
+
+func (l *LiteralExpr) Pos() int { return l.Offset }
+
+// StarExpr represents `*` as an expression. Parse does not produce it:
+// a `*` select item is a SelectItem with Star set.
+type StarExpr struct {
+	Offset int
+}
+
+func (s *StarExpr) Pos() int { return s.Offset }
+
+// FuncCall is a function call expression: `name(args...)`.
+type FuncCall struct {
+	Name   string
+	Args   []Expr
+	Offset int
+}
+
+func (f *FuncCall) Pos() int { return f.Offset }
+
+// UnaryExpr is a prefix expression, currently only `NOT expr`.
+type UnaryExpr struct {
+	Op     TokenType
+	Expr   Expr
+	Offset int
+}
+
+func (u *UnaryExpr) Pos() int { return u.Offset }
+
+// BinaryExpr is `left op right`. Op is either a keyword TokenType (And,
+// Or) or Op, in which case Literal holds the operator text (e.g. "<=").
+type BinaryExpr struct {
+	Op      TokenType
+	Literal string
+	Left    Expr
+	Right   Expr
+	Offset  int
+}
+
+func (b *BinaryExpr) Pos() int { return b.Offset }
+
+// BetweenExpr is `expr [NOT] BETWEEN low AND high`.
+type BetweenExpr struct {
+	Expr   Expr
+	Not    bool
+	Low    Expr
+	High   Expr
+	Offset int
+}
+
+func (b *BetweenExpr) Pos() int { return b.Offset }
+
+// LikeExpr is `expr [NOT] LIKE pattern`.
+type LikeExpr struct {
+	Expr    Expr
+	Not     bool
+	Pattern Expr
+	Offset  int
+}
+
+func (l *LikeExpr) Pos() int { return l.Offset }
+
+// IsNullExpr is `expr IS [NOT] NULL`.
+type IsNullExpr struct {
+	Expr   Expr
+	Not    bool
+	Offset int
+}
+
+func (i *IsNullExpr) Pos() int { return i.Offset }
+
+// InExpr is `expr [NOT] IN (list...)`.
+type InExpr struct {
+	Expr   Expr
+	Not    bool
+	List   []Expr
+	Offset int
+}
+
+func (i *InExpr) Pos() int { return i.Offset }
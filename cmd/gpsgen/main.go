@@ -0,0 +1,429 @@
+// Command gpsgen generates sql_lex_generated.go: a specialized,
+// allocation-free lexer derived from the keyword table in lex.go.
+//
+// Unlike Lexer (which dispatches through State functions and looks up
+// keywords with strings.ToUpper plus a map probe), the generated
+// lexer runs as a single inlined scan loop over a []byte and matches
+// keywords with a length-bucketed switch of direct byte comparisons,
+// built at generation time from gps.Keywords. Token.Literal aliases
+// the input as a []byte rather than a string, so draining it in a hot
+// path allocates nothing beyond the token slice itself.
+//
+// Run it with `go generate ./...` (see the //go:generate directive
+// next to Keywords in lex.go) whenever the keyword table changes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/onesuper/gps"
+)
+
+func main() {
+	out := flag.String("out", "sql_lex_generated.go", "output file")
+	flag.Parse()
+
+	src, err := generate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gpsgen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "gpsgen:", err)
+		os.Exit(1)
+	}
+}
+
+func generate() ([]byte, error) {
+	src := header + keywordSwitch() + body
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated source: %w", err)
+	}
+	return formatted, nil
+}
+
+// keywordSwitch emits lookupGeneratedKeyword: a switch on identifier
+// length, with one direct byte-compare per keyword of that length
+// inside each case. Grouping by length first means most candidates
+// are ruled out by a single int comparison before any byte is
+// examined, so this behaves like the top level of a trie without
+// requiring one.
+//
+// Each keyword's Go identifier (e.g. "Select" for "SELECT") is
+// derived from the word itself rather than looked up, since every
+// TokenType keyword constant in lex.go is named exactly that way.
+func keywordSwitch() string {
+	byLen := map[int][]string{}
+	for word := range gps.Keywords {
+		byLen[len(word)] = append(byLen[len(word)], word)
+	}
+	lengths := make([]int, 0, len(byLen))
+	for n := range byLen {
+		lengths = append(lengths, n)
+	}
+	sort.Ints(lengths)
+
+	var b strings.Builder
+	b.WriteString("func lookupGeneratedKeyword(word []byte) (TokenType, bool) {\n")
+	b.WriteString("\tswitch len(word) {\n")
+	for _, n := range lengths {
+		words := byLen[n]
+		sort.Strings(words)
+		fmt.Fprintf(&b, "\tcase %d:\n", n)
+		for _, word := range words {
+			fmt.Fprintf(&b, "\t\tif eqFold(word, %q) {\n\t\t\treturn %s, true\n\t\t}\n", word, goIdent(word))
+		}
+	}
+	b.WriteString("\t}\n")
+	b.WriteString("\treturn 0, false\n")
+	b.WriteString("}\n\n")
+	return b.String()
+}
+
+// goIdent turns a reserved word like "SELECT" into the Go identifier
+// its TokenType constant is declared under: "Select".
+func goIdent(word string) string {
+	return word[:1] + strings.ToLower(word[1:])
+}
+
+const header = `// Code generated by gpsgen from the keyword table in lex.go; DO NOT EDIT.
+
+package gps
+
+import "errors"
+
+// GeneratedToken is the token produced by GeneratedLexer. It has the
+// same shape as Token, except Literal aliases the input as a []byte
+// instead of a string, so a caller that only inspects bytes (routing
+// on a keyword, say) doesn't pay for a string conversion.
+type GeneratedToken struct {
+	Type    TokenType
+	Literal []byte
+	Pos     Position
+	End     int
+}
+
+// GeneratedLexer is a specialized lexer generated from the same
+// grammar as Lexer: see cmd/gpsgen. It supports the token set the
+// hand-written lexer accepted at generation time, except for the
+// stateful/rule-driven extensions in stateful.go. Comments are always
+// skipped: it never emits Comment tokens and has no IncludeComments
+// option. It has no channel and never calls strings.ToUpper, so Next
+// allocates nothing beyond the returned GeneratedToken.
+type GeneratedLexer struct {
+	input []byte
+	pos   int
+	start int
+
+	line, col           int
+	startLine, startCol int
+}
+
+// NewGeneratedLexer creates a GeneratedLexer over input. input is
+// converted to []byte once, here, rather than on every token.
+func NewGeneratedLexer(input string) *GeneratedLexer {
+	return &GeneratedLexer{
+		input:     []byte(input),
+		line:      1,
+		col:       1,
+		startLine: 1,
+		startCol:  1,
+	}
+}
+
+var (
+	errGeneratedEOF   = errors.New("EOF")
+	errGeneratedError = errors.New("Error")
+)
+
+// generatedTokenErr mirrors tokenErr for GeneratedToken, returning one
+// of two package-level sentinel errors instead of allocating a new
+// one per call.
+func generatedTokenErr(t TokenType) error {
+	switch t {
+	case Error:
+		return errGeneratedError
+	case EOF:
+		return errGeneratedEOF
+	}
+	return nil
+}
+
+`
+
+const body = `
+// Next returns the next token from the input. Its error return
+// follows Lexer.Next: nil for an ordinary token, non-nil (but still
+// carrying a valid token) for an Error or EOF token.
+func (g *GeneratedLexer) Next() (GeneratedToken, error) {
+	for {
+		if g.pos >= len(g.input) {
+			return g.token(EOF)
+		}
+
+		switch c := g.input[g.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			g.advance()
+			g.ignore()
+			continue
+		case c == '\'':
+			return g.scanQuoted('\'', String)
+		case c == '\x60':
+			return g.scanQuoted('\x60', Literal)
+		case c == '"':
+			return g.scanQuoted('"', DblQuotedIdent)
+		case c == '-' && g.peek(1) == '-':
+			g.advance()
+			g.advance()
+			g.skipLineComment()
+			continue
+		case c == '/' && g.peek(1) == '*':
+			if !g.skipBlockComment() {
+				return g.token(Error)
+			}
+			continue
+		case c == '*':
+			g.advance()
+			return g.token(Star)
+		case c == ',':
+			g.advance()
+			return g.token(Sep)
+		case c == '.':
+			g.advance()
+			return g.token(Dot)
+		case c == '(' || c == ')':
+			g.advance()
+			return g.token(Paren)
+		case c == '=' || c == '+' || c == '-' || c == '/':
+			g.advance()
+			return g.token(Op)
+		case c == '%':
+			g.advance()
+			return g.token(Mod)
+		case c == '&':
+			g.advance()
+			return g.token(BitAnd)
+		case c == '^':
+			g.advance()
+			return g.token(BitXor)
+		case c == '|':
+			g.advance()
+			if g.pos < len(g.input) && g.input[g.pos] == '|' {
+				g.advance()
+				return g.token(Concat)
+			}
+			return g.token(BitOr)
+		case c == '!':
+			g.advance()
+			if g.pos < len(g.input) && g.input[g.pos] == '=' {
+				g.advance()
+				return g.token(Op)
+			}
+			return g.token(Error)
+		case c == '>':
+			g.advance()
+			if g.pos < len(g.input) {
+				switch g.input[g.pos] {
+				case '=':
+					g.advance()
+				case '>':
+					g.advance()
+					return g.token(ShiftR)
+				}
+			}
+			return g.token(Op)
+		case c == '<':
+			g.advance()
+			if g.pos < len(g.input) {
+				switch g.input[g.pos] {
+				case '=', '>':
+					g.advance()
+				case '<':
+					g.advance()
+					return g.token(ShiftL)
+				}
+			}
+			return g.token(Op)
+		case '0' <= c && c <= '9':
+			return g.scanNumber()
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+			return g.scanIdentOrKeyword()
+		default:
+			g.advance()
+			tok, err := g.token(Error)
+			g.recover()
+			return tok, err
+		}
+	}
+}
+
+// recover skips past a bad lexeme so a single unscannable byte doesn't
+// cascade into spurious tokens for the rest of the word, mirroring
+// Lexer.recover: it advances to the next whitespace or separator-like
+// byte, or EOF, and resumes scanning from there.
+func (g *GeneratedLexer) recover() {
+	for g.pos < len(g.input) {
+		switch g.input[g.pos] {
+		case ' ', '\n', ',', '(', ')':
+			g.ignore()
+			return
+		}
+		g.advance()
+	}
+	g.ignore()
+}
+
+// token builds a GeneratedToken spanning g.start:g.pos, starts the
+// next lexeme at g.pos, and pairs it with the error Next should
+// return alongside it.
+func (g *GeneratedLexer) token(t TokenType) (GeneratedToken, error) {
+	tok := GeneratedToken{
+		Type:    t,
+		Literal: g.input[g.start:g.pos],
+		Pos:     Position{Offset: g.start, Line: g.startLine, Col: g.startCol},
+		End:     g.pos,
+	}
+	g.start = g.pos
+	g.startLine = g.line
+	g.startCol = g.col
+	return tok, generatedTokenErr(t)
+}
+
+// ignore starts the next lexeme at g.pos without emitting a token for
+// what came before it (whitespace, a skipped comment).
+func (g *GeneratedLexer) ignore() {
+	g.start = g.pos
+	g.startLine = g.line
+	g.startCol = g.col
+}
+
+// advance consumes the current byte, updating line/col if it's a
+// newline.
+func (g *GeneratedLexer) advance() {
+	if g.input[g.pos] == '\n' {
+		g.line++
+		g.col = 1
+	} else {
+		g.col++
+	}
+	g.pos++
+}
+
+// peek looks n bytes past the cursor without consuming anything, or
+// returns 0 past the end of input.
+func (g *GeneratedLexer) peek(n int) byte {
+	if g.pos+n >= len(g.input) {
+		return 0
+	}
+	return g.input[g.pos+n]
+}
+
+// scanQuoted scans a quote-delimited literal opened by the byte at
+// the cursor, handling a doubled quote (an escaped quote) the same way
+// expectString, expectLiteral and expectDblQuoted do, and, in a
+// '...' string only, a backslash escape as expectString does.
+// Running off the end of input without a closing quote is an error.
+func (g *GeneratedLexer) scanQuoted(quote byte, t TokenType) (GeneratedToken, error) {
+	g.advance() // opening quote
+	for {
+		if g.pos >= len(g.input) {
+			return g.token(Error)
+		}
+		c := g.input[g.pos]
+		g.advance()
+		if c == '\\' && quote == '\'' {
+			if g.pos < len(g.input) {
+				g.advance()
+			}
+			continue
+		}
+		if c == quote {
+			if g.pos < len(g.input) && g.input[g.pos] == quote {
+				g.advance()
+				continue
+			}
+			return g.token(t)
+		}
+	}
+}
+
+// skipLineComment consumes a "-- ..." comment through end of line (or
+// EOF); the caller has already consumed the leading "--".
+func (g *GeneratedLexer) skipLineComment() {
+	for g.pos < len(g.input) && g.input[g.pos] != '\n' {
+		g.advance()
+	}
+	g.ignore()
+}
+
+// skipBlockComment consumes a "/* ... */" comment, including its
+// delimiters. It reports false if input runs out before a closing
+// "*/" is found.
+func (g *GeneratedLexer) skipBlockComment() bool {
+	g.advance() // '/'
+	g.advance() // '*'
+	for {
+		if g.pos >= len(g.input) {
+			return false
+		}
+		if g.input[g.pos] == '*' && g.peek(1) == '/' {
+			g.advance()
+			g.advance()
+			g.ignore()
+			return true
+		}
+		g.advance()
+	}
+}
+
+func (g *GeneratedLexer) scanNumber() (GeneratedToken, error) {
+	for g.pos < len(g.input) && '0' <= g.input[g.pos] && g.input[g.pos] <= '9' {
+		g.advance()
+	}
+	if g.pos < len(g.input) && g.input[g.pos] == '.' {
+		g.advance()
+		for g.pos < len(g.input) && '0' <= g.input[g.pos] && g.input[g.pos] <= '9' {
+			g.advance()
+		}
+	}
+	return g.token(Number)
+}
+
+func (g *GeneratedLexer) scanIdentOrKeyword() (GeneratedToken, error) {
+	for g.pos < len(g.input) {
+		c := g.input[g.pos]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_') {
+			break
+		}
+		g.advance()
+	}
+	if t, ok := lookupGeneratedKeyword(g.input[g.start:g.pos]); ok {
+		return g.token(t)
+	}
+	return g.token(Ident)
+}
+
+// eqFold reports whether b equals the uppercase string s, comparing
+// case-insensitively without allocating (no strings.ToUpper). s is
+// always an uppercase ASCII literal baked in at generation time, and
+// the caller has already matched len(b) == len(s) via the switch in
+// lookupGeneratedKeyword.
+func eqFold(b []byte, s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := b[i]
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c != s[i] {
+			return false
+		}
+	}
+	return true
+}
+`
@@ -23,6 +23,15 @@ const (
 	Number
 	String
 	DblString
+	DblQuotedIdent
+	Comment
+	Concat
+	Mod
+	BitAnd
+	BitOr
+	BitXor
+	ShiftL
+	ShiftR
 	// KeyWord
 	Select
 	Distinct
@@ -52,11 +61,47 @@ const (
 	Not
 	Like
 	Exists
+	In
+	Asc
+	Desc
+	Ident
 )
 
+// Position locates a byte within a Lexer's input, both as a raw offset
+// and as the 1-based line/column a human would use to find it.
+type Position struct {
+	Offset int
+	Line   int
+	Col    int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
 type Token struct {
-	Type    TokenType
+	Type TokenType
+	// Literal aliases input[Pos.Offset:End]; it shares the input's
+	// backing array rather than copying, so producing a token is
+	// allocation-free. The exception is an Error token, whose Literal
+	// is a formatted error message.
 	Literal string
+	Pos     Position
+	// End is the byte offset one past the token's last rune.
+	End int
+}
+
+// LexError is a diagnostic recorded while scanning. Unlike an Error
+// token (which callers see interleaved in the normal token stream),
+// LexErrors accumulate on the Lexer so a caller can collect every
+// problem found in one pass instead of stopping at the first one.
+type LexError struct {
+	Message string
+	Pos     Position
+}
+
+func (e LexError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
 }
 
 func (t *Token) String() string {
@@ -70,31 +115,150 @@ func (t *Token) String() string {
 }
 
 type Lexer struct {
-	name   string
-	input  string
-	start  int
-	pos    int
+	name  string
+	input string
+	start int
+	pos   int
+
+	// line, col is the 1-based line/column of l.pos; startLine, startCol
+	// is the same for l.start, snapshotted by markStart whenever a new
+	// token begins. Keeping both lets emit attach the *start* position
+	// to a token even though scanning it moves pos (and line/col) on.
+	line, col           int
+	startLine, startCol int
+
+	// state is the next state function to run when Next needs more
+	// tokens; nil once the input is exhausted.
+	state State
+	// pending/hasPending hold a token a state has produced but Next
+	// hasn't returned yet. A single call into a state function emits at
+	// most one token before yielding control back to Next, so a
+	// single-slot field is enough: no per-token slice growth.
+	pending    Token
+	hasPending bool
+
+	// errs accumulates every LexError found while scanning, so a
+	// caller can collect all of them in one pass via Errors.
+	errs []LexError
+
+	// tokens and done are only set on a Lexer created by
+	// NewLexerAsync; they drive the legacy channel-based API.
 	tokens chan Token
+	done   bool
+
+	// rules and modeStack are only set on a Lexer created by
+	// NewStatefulLexer; they drive statefulStep (see stateful.go).
+	rules     Rules
+	modeStack []string
+
+	// IncludeComments, if set before the first call to Next, makes the
+	// lexer emit Comment tokens instead of discarding them. Off by
+	// default since most callers (the parser) don't care about
+	// comments; formatters and other source-preserving tools want them.
+	IncludeComments bool
 }
 
+// NewLexer creates a Lexer that produces tokens synchronously: Next
+// drives the state machine in the calling goroutine and returns as soon
+// as a token is ready, with no channel or background goroutine involved.
 func NewLexer(name, input string) *Lexer {
+	return &Lexer{
+		name:      name,
+		input:     input,
+		state:     expectAny,
+		line:      1,
+		col:       1,
+		startLine: 1,
+		startCol:  1,
+	}
+}
+
+// Errors returns every LexError recorded so far. A bad token doesn't
+// stop scanning: Next skips past it and keeps going, so callers that
+// want every diagnostic in one pass should drain the Lexer (e.g. with
+// ConsumeAll) and then call Errors.
+func (l *Lexer) Errors() []LexError {
+	return l.errs
+}
+
+// NewLexerAsync creates a Lexer that runs the state machine on its own
+// goroutine and delivers tokens over an unbuffered channel, the way
+// NewLexer used to work. It exists for callers that relied on that
+// concurrency model; new code should prefer NewLexer and Next.
+func NewLexerAsync(name, input string) *Lexer {
 	l := &Lexer{
-		name:   name,
-		input:  input,
-		tokens: make(chan Token),
+		name:      name,
+		input:     input,
+		tokens:    make(chan Token),
+		line:      1,
+		col:       1,
+		startLine: 1,
+		startCol:  1,
 	}
 	go l.transform()
 	return l
 }
 
+// Next returns the next token from the input. Its error return is nil
+// for an ordinary token, and non-nil (but still carrying a valid Token)
+// for an Error or EOF token, matching the old Tokenize behavior.
+func (l *Lexer) Next() (Token, error) {
+	if l.tokens != nil {
+		t := <-l.tokens
+		return t, tokenErr(t)
+	}
+
+	for !l.hasPending && l.state != nil {
+		l.state = l.state(l)
+	}
+
+	if !l.hasPending {
+		// The state machine is exhausted without emitting a final EOF:
+		// an unterminated string, literal or block comment stops it this
+		// way after its Error token, so produce the EOF here.
+		pos := Position{Offset: l.pos, Line: l.line, Col: l.col}
+		return Token{Type: EOF, Pos: pos, End: l.pos}, errors.New("EOF")
+	}
+
+	t := l.pending
+	l.hasPending = false
+	return t, tokenErr(t)
+}
+
+// Tokenize is a deprecated alias for Next, kept so existing callers
+// don't need to change.
 func (l *Lexer) Tokenize() (Token, error) {
-	t := <-l.tokens
-	if t.Type == Error {
-		return t, errors.New("Error")
-	} else if t.Type == EOF {
-		return t, errors.New("EOF")
+	return l.Next()
+}
+
+func tokenErr(t Token) error {
+	switch t.Type {
+	case Error:
+		return errors.New("Error")
+	case EOF:
+		return errors.New("EOF")
+	}
+	return nil
+}
+
+// ConsumeAll drains l and returns every token it produces, including
+// any Error tokens along the way (the lexer recovers from those and
+// keeps going), stopping once EOF is reached. It preallocates for the
+// common case of a realistic query so repeated appends don't
+// reallocate the slice. The returned error is non-nil only if l never
+// reaches EOF; check l.Errors() for lex errors found along the way.
+func ConsumeAll(l *Lexer) ([]Token, error) {
+	toks := make([]Token, 0, 1024)
+	for {
+		t, err := l.Next()
+		toks = append(toks, t)
+		if t.Type == EOF {
+			return toks, nil
+		}
+		if err != nil && t.Type != Error {
+			return toks, err
+		}
 	}
-	return t, nil
 }
 
 func (l *Lexer) debugString() string {
@@ -118,8 +282,35 @@ func (l *Lexer) debugString() string {
 // When we recognize a token, we move on with the cursor and
 // call this func to emit it back to the caller.
 func (l *Lexer) emit(t TokenType) {
-	l.tokens <- Token{t, l.cache()}
+	l.deliver(Token{t, l.cache(), l.startPos(), l.pos})
+	l.markStart()
+}
+
+// startPos returns the Position of l.start, the first byte of the
+// lexeme currently being scanned.
+func (l *Lexer) startPos() Position {
+	return Position{Offset: l.start, Line: l.startLine, Col: l.startCol}
+}
+
+// markStart begins scanning a new lexeme at the current cursor,
+// snapshotting its line/column so emit/errorf can attach them to
+// whatever token comes out of it.
+func (l *Lexer) markStart() {
 	l.start = l.pos
+	l.startLine = l.line
+	l.startCol = l.col
+}
+
+// deliver hands a token to whichever API the Lexer was constructed
+// with: the channel for NewLexerAsync, or the pending slot that Next
+// drains for NewLexer.
+func (l *Lexer) deliver(t Token) {
+	if l.tokens != nil {
+		l.tokens <- t
+		return
+	}
+	l.pending = t
+	l.hasPending = true
 }
 
 func (l *Lexer) cache() string {
@@ -128,13 +319,19 @@ func (l *Lexer) cache() string {
 
 // ignore the current prune
 func (l *Lexer) ignore() {
-	l.start = l.pos
+	l.markStart()
 }
 
 // eat the next Rune from input
 func (l *Lexer) next() rune {
 	r, width := utf8.DecodeRuneInString(l.input[l.pos:])
 	l.pos += width
+	if r == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
 	// log.Printf("[next] %s", l.debugString())
 	return r
 }
@@ -142,6 +339,8 @@ func (l *Lexer) next() rune {
 // backup to the start
 func (l *Lexer) backup() {
 	l.pos = l.start
+	l.line = l.startLine
+	l.col = l.startCol
 	// log.Printf("[back] %s", l.debugString())
 }
 
@@ -160,16 +359,40 @@ func (l *Lexer) accept(valid string) bool {
 	return false
 }
 
-// put the error message to the token literal
-func (l *Lexer) errorf(format string, args interface{}) {
-	prefixed := fmt.Sprintf("ERROR: %s: %s", l.name, format)
-	l.tokens <- Token{Error, fmt.Sprintf(prefixed, args)}
+// errorf records a LexError at the start of the current lexeme and
+// emits a matching Error token with the same message, so a caller can
+// see it either way: inline in the token stream, or all together via
+// Errors after draining the Lexer.
+func (l *Lexer) errorf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	pos := l.startPos()
+	l.errs = append(l.errs, LexError{Message: msg, Pos: pos})
+	l.deliver(Token{Error, fmt.Sprintf("ERROR: %s: %s", l.name, msg), pos, l.pos})
+}
+
+// recover skips past a bad lexeme so a single unscannable rune (or
+// unsupported operator) doesn't end the whole stream: it advances to
+// the next whitespace or separator-like rune, or EOF, and resumes
+// scanning from there.
+func (l *Lexer) recover() State {
+	for l.pos < len(l.input) {
+		switch l.peek() {
+		case ' ', '\n', ',', '(', ')':
+			l.ignore()
+			return expectAny
+		}
+		l.next()
+	}
+	l.ignore()
+	return expectAny
 }
 
 // The state is a function which takes as input the lexer and return
 // a state, which takes as input the lexer and return a state...
 type State func(*Lexer) State
 
+// transform drives the state machine to completion on its own
+// goroutine; only a Lexer created by NewLexerAsync runs this.
 func (l *Lexer) transform() {
 	for state := expectAny; state != nil; {
 		state = state(l)
@@ -189,7 +412,9 @@ func expectAny(l *Lexer) State {
 		return expectString
 	case r == '`':
 		return expectLiteral
-	case r == ' ' || r == '\n':
+	case r == '"':
+		return expectDblQuoted
+	case r == ' ' || r == '\n' || r == '\t' || r == '\r':
 		l.ignore()
 		return expectAny
 	case r == '*':
@@ -198,24 +423,73 @@ func expectAny(l *Lexer) State {
 	case r == ',':
 		l.emit(Sep)
 		return expectAny(l)
-	case r == '=' || r == '+' || r == '-' || r == '/':
+	case r == '.':
+		l.emit(Dot)
+		return expectAny
+	case r == '(' || r == ')':
+		l.emit(Paren)
+		return expectAny
+	case r == '=' || r == '+':
+		l.emit(Op)
+		return expectAny
+	case r == '-':
+		if l.peek() == '-' {
+			l.next()
+			return expectLineComment
+		}
 		l.emit(Op)
 		return expectAny
+	case r == '/':
+		if l.peek() == '*' {
+			l.next()
+			return expectBlockComment
+		}
+		l.emit(Op)
+		return expectAny
+	case r == '%':
+		l.emit(Mod)
+		return expectAny
+	case r == '&':
+		l.emit(BitAnd)
+		return expectAny
+	case r == '^':
+		l.emit(BitXor)
+		return expectAny
+	case r == '|':
+		if l.peek() == '|' {
+			l.next()
+			l.emit(Concat)
+		} else {
+			l.emit(BitOr)
+		}
+		return expectAny
 	case r == '!':
 		if l.peek() != '=' {
-			l.errorf("unsupported op: ", l.cache())
+			l.errorf("unsupported op: %q", l.cache())
+			return l.recover()
 		}
+		l.next()
 		l.emit(Op)
 		return expectAny
 	case r == '>':
-		if l.peek() != '=' && l.peek() != ' ' {
-			l.errorf("unsupported op: ", l.cache())
+		switch l.peek() {
+		case '=':
+			l.next()
+		case '>':
+			l.next()
+			l.emit(ShiftR)
+			return expectAny
 		}
 		l.emit(Op)
 		return expectAny
 	case r == '<':
-		if l.peek() != '=' && l.peek() != ' ' && l.peek() != '>' {
-			l.errorf("unsupported op: ", l.cache())
+		switch l.peek() {
+		case '=', '>':
+			l.next()
+		case '<':
+			l.next()
+			l.emit(ShiftL)
+			return expectAny
 		}
 		l.emit(Op)
 		return expectAny
@@ -226,75 +500,173 @@ func expectAny(l *Lexer) State {
 		l.backup()
 		return expectKeyword
 	default:
-		return nil
+		l.errorf("unexpected character %q", string(r))
+		return l.recover()
 	}
 }
 
+//go:generate go run ./cmd/gpsgen -out sql_lex_generated.go
+
+// Keywords maps every reserved word to its TokenType. It's shared by
+// the hand-written lexer (expectKeyword), the rule-driven stateful
+// lexer (stateful.go), and gpsgen (cmd/gpsgen), so none of them can
+// drift from what's reserved. Exported so gpsgen can read it without
+// duplicating the table.
+var Keywords = map[string]TokenType{
+	"SELECT":   Select,
+	"DISTINCT": Distinct,
+	"FROM":     From,
+	"WHERE":    Where,
+	"GROUP":    Group,
+	"ORDER":    Order,
+	"BY":       By,
+	"HAVING":   Having,
+	"LIMIT":    Limit,
+	"JOIN":     Join,
+	"LEFT":     Left,
+	"RIGHT":    Right,
+	"INNER":    Inner,
+	"OUTER":    Outer,
+	"ON":       On,
+	"AS":       As,
+	"UNION":    Union,
+	"ALL":      All,
+	"AND":      And,
+	"OR":       Or,
+	"BETWEEN":  Between,
+	"TRUE":     True,
+	"FALSE":    False,
+	"NULL":     Null,
+	"IS":       Is,
+	"NOT":      Not,
+	"LIKE":     Like,
+	"EXISTS":   Exists,
+	"IN":       In,
+	"ASC":      Asc,
+	"DESC":     Desc,
+}
+
+// lookupKeyword returns the TokenType for a reserved word (matched
+// case-insensitively), or false if word isn't one.
+func lookupKeyword(word string) (TokenType, bool) {
+	t, ok := Keywords[strings.ToUpper(word)]
+	return t, ok
+}
+
 func expectKeyword(l *Lexer) State {
-	for r := l.peek(); 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'; r = l.peek() {
+	for r := l.peek(); 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_'; r = l.peek() {
 		l.next()
 	}
 
-	switch strings.ToUpper(l.cache()) {
-	case "SELECT":
-		l.emit(Select)
-	case "DISTINCT":
-		l.emit(Distinct)
-	case "FROM":
-		l.emit(From)
-	case "WHERE":
-		l.emit(Where)
-	case "GROUP":
-		l.emit(Group)
-	case "ORDER":
-		l.emit(Order)
-	case "BY":
-		l.emit(By)
-	case "HAVING":
-		l.emit(Having)
-	case "LIMIT":
-		l.emit(Limit)
-	case "JOIN":
-		l.emit(Join)
-	case "LEFT":
-		l.emit(Left)
-	case "RIGHT":
-		l.emit(Right)
-	case "INNER":
-		l.emit(Inner)
-	case "OUTER":
-		l.emit(Outer)
-	case "ON":
-		l.emit(On)
-	case "AS":
-		l.emit(As)
-	case "UNION":
-		l.emit(Union)
-	case "ALL":
-		l.emit(All)
-	default:
-		l.errorf("keyword doesn't exsit: %s", l.cache())
-		return nil
+	if t, ok := lookupKeyword(l.cache()); ok {
+		l.emit(t)
+	} else {
+		// Not a reserved word: treat it as a plain (unquoted) identifier,
+		// e.g. a column or table name.
+		l.emit(Ident)
 	}
 	return expectAny
 }
 
+// expectString scans a '...'-quoted string literal. A doubled quote
+// (”) or a backslash escape (\x) is consumed as part of the literal
+// rather than ending it; running off the end of the input without a
+// closing quote is an error rather than an infinite loop. Returning
+// nil here (instead of also emitting EOF) leaves Next's own
+// state-exhausted fallback to produce the final EOF token, since a
+// single call into a state only delivers one token.
 func expectString(l *Lexer) State {
-	if l.next() == '\'' {
-		l.emit(String)
-		return expectAny
-	} else {
-		return expectString
+	for {
+		if l.pos >= len(l.input) {
+			l.errorf("unterminated string literal")
+			return nil
+		}
+		switch l.next() {
+		case '\\':
+			if l.pos < len(l.input) {
+				l.next()
+			}
+		case '\'':
+			if l.peek() == '\'' {
+				l.next()
+				continue
+			}
+			l.emit(String)
+			return expectAny
+		}
 	}
 }
 
+// expectLiteral scans a `...`-quoted literal (e.g. a backtick-quoted
+// identifier). A doubled backtick escapes a literal backtick.
 func expectLiteral(l *Lexer) State {
-	if l.next() == '`' {
-		l.tokens <- Token{t, l.cache()}
-		l.start = l.pos
-		return expectAny
+	for {
+		if l.pos >= len(l.input) {
+			l.errorf("unterminated literal")
+			return nil
+		}
+		if l.next() == '`' {
+			if l.peek() == '`' {
+				l.next()
+				continue
+			}
+			l.emit(Literal)
+			return expectAny
+		}
+	}
+}
+
+// expectDblQuoted scans a "..."-quoted identifier. A doubled double-quote
+// escapes a literal double-quote, matching expectString and expectLiteral.
+func expectDblQuoted(l *Lexer) State {
+	for {
+		if l.pos >= len(l.input) {
+			l.errorf("unterminated quoted identifier")
+			return nil
+		}
+		if l.next() == '"' {
+			if l.peek() == '"' {
+				l.next()
+				continue
+			}
+			l.emit(DblQuotedIdent)
+			return expectAny
+		}
+	}
+}
+
+// expectLineComment scans a "-- ..." comment through end of line (or
+// EOF). IncludeComments decides whether it's emitted or discarded.
+func expectLineComment(l *Lexer) State {
+	for l.pos < len(l.input) && l.peek() != '\n' {
+		l.next()
+	}
+	if l.IncludeComments {
+		l.emit(Comment)
 	} else {
-		return expectLiteral
+		l.ignore()
+	}
+	return expectAny
+}
+
+// expectBlockComment scans a "/* ... */" comment. Running off the end
+// of the input without a closing "*/" is an error rather than an
+// infinite loop.
+func expectBlockComment(l *Lexer) State {
+	for {
+		if l.pos >= len(l.input) {
+			l.errorf("unterminated block comment")
+			return nil
+		}
+		if l.next() == '*' && l.peek() == '/' {
+			l.next()
+			if l.IncludeComments {
+				l.emit(Comment)
+			} else {
+				l.ignore()
+			}
+			return expectAny
+		}
 	}
 }
 
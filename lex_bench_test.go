@@ -0,0 +1,45 @@
+package gps
+
+import "testing"
+
+const benchQuery = "select a, b, c from orders where a = 10 and b <> 'x' order by a limit 10"
+
+func BenchmarkLexNext(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		l := NewLexer("bench", benchQuery)
+		for {
+			_, err := l.Next()
+			if err != nil {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkLexAsync(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		l := NewLexerAsync("bench", benchQuery)
+		for {
+			_, err := l.Tokenize()
+			if err != nil {
+				break
+			}
+		}
+	}
+}
+
+// BenchmarkGeneratedLexer drains the same query with GeneratedLexer,
+// for comparison against BenchmarkLexNext: keyword matching is a
+// byte-compare switch instead of strings.ToUpper plus a map probe, and
+// Literal aliases the input as a []byte instead of a string.
+func BenchmarkGeneratedLexer(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		g := NewGeneratedLexer(benchQuery)
+		for {
+			tok, err := g.Next()
+			if tok.Type == EOF || err != nil {
+				break
+			}
+		}
+	}
+}
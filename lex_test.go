@@ -21,3 +21,172 @@ func TestLex(t *testing.T) {
 	toks := getAllTokens(l)
 	fmt.Println(toks)
 }
+
+func TestConsumeAll(t *testing.T) {
+	l := NewLexer("test", "select a from b")
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+	if len(toks) == 0 || toks[len(toks)-1].Type != EOF {
+		t.Fatalf("expected the stream to end with EOF, got %v", toks)
+	}
+}
+
+func TestLexTracksLineAndColumn(t *testing.T) {
+	l := NewLexer("test", "select a\nfrom b")
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+	// toks: SELECT, a, FROM, b, EOF
+	from := toks[2]
+	if from.Pos.Line != 2 || from.Pos.Col != 1 {
+		t.Fatalf("expected FROM at line 2, col 1, got %+v", from.Pos)
+	}
+}
+
+func TestLexRecoversFromBadCharacter(t *testing.T) {
+	l := NewLexer("test", "select a ~ b from t")
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+	var sawError, sawFrom bool
+	for _, tok := range toks {
+		if tok.Type == Error {
+			sawError = true
+		}
+		if tok.Type == From {
+			sawFrom = true
+		}
+	}
+	if !sawError || !sawFrom {
+		t.Fatalf("expected to see both an Error token and a later FROM token, got %v", toks)
+	}
+	if errs := l.Errors(); len(errs) != 1 {
+		t.Fatalf("expected exactly one recorded LexError, got %v", errs)
+	}
+}
+
+func TestLexStringEscapes(t *testing.T) {
+	l := NewLexer("test", `select 'it''s a \'quoted\' test'`)
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+	if len(toks) != 3 || toks[1].Type != String {
+		t.Fatalf("expected [SELECT, String, EOF], got %v", toks)
+	}
+	if toks[1].Literal != `'it''s a \'quoted\' test'` {
+		t.Fatalf("expected the whole escaped literal, got %q", toks[1].Literal)
+	}
+}
+
+func TestLexUnterminatedStringDoesNotHang(t *testing.T) {
+	l := NewLexer("test", "select 'abc")
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+	if len(toks) != 3 || toks[1].Type != Error || toks[2].Type != EOF {
+		t.Fatalf("expected [SELECT, Error, EOF], got %v", toks)
+	}
+}
+
+func TestLexDblQuotedIdent(t *testing.T) {
+	l := NewLexer("test", `select "my col" from t`)
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+	if toks[1].Type != DblQuotedIdent || toks[1].Literal != `"my col"` {
+		t.Fatalf("expected a DblQuotedIdent token, got %+v", toks[1])
+	}
+}
+
+func TestLexCommentsAreSkippedByDefault(t *testing.T) {
+	l := NewLexer("test", "select a -- trailing\nfrom /* block */ t")
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+	for _, tok := range toks {
+		if tok.Type == Comment {
+			t.Fatalf("expected comments to be discarded, got %v", toks)
+		}
+	}
+}
+
+func TestLexIncludeComments(t *testing.T) {
+	l := NewLexer("test", "select a -- trailing\nfrom t")
+	l.IncludeComments = true
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+	var saw bool
+	for _, tok := range toks {
+		if tok.Type == Comment {
+			saw = true
+			if tok.Literal != "-- trailing" {
+				t.Fatalf("expected the comment literal to exclude the newline, got %q", tok.Literal)
+			}
+		}
+	}
+	if !saw {
+		t.Fatalf("expected a Comment token when IncludeComments is set, got %v", toks)
+	}
+}
+
+func TestLexOperators(t *testing.T) {
+	l := NewLexer("test", "a >= b <= c <> d != e << f >> g || h & i | j ^ k % 2")
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+
+	var ops []TokenType
+	for _, tok := range toks {
+		if tok.Type != Ident && tok.Type != Number && tok.Type != EOF {
+			ops = append(ops, tok.Type)
+		}
+	}
+	want := []TokenType{Op, Op, Op, Op, ShiftL, ShiftR, Concat, BitAnd, BitOr, BitXor, Mod}
+	if len(ops) != len(want) {
+		t.Fatalf("expected %d operator tokens, got %d: %v", len(want), len(ops), ops)
+	}
+	for i := range want {
+		if ops[i] != want[i] {
+			t.Fatalf("operator %d: expected %d, got %d", i, want[i], ops[i])
+		}
+	}
+}
+
+func TestLexAsyncMatchesSync(t *testing.T) {
+	input := "select a from b where a = 1"
+	sync := NewLexer("sync", input)
+	syncToks, err := ConsumeAll(sync)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+
+	async := NewLexerAsync("async", input)
+	var asyncToks []Token
+	for {
+		tok, err := async.Tokenize()
+		asyncToks = append(asyncToks, tok)
+		if err != nil {
+			break
+		}
+	}
+
+	if len(syncToks) != len(asyncToks) {
+		t.Fatalf("sync produced %d tokens, async produced %d", len(syncToks), len(asyncToks))
+	}
+	for i := range syncToks {
+		if syncToks[i].Type != asyncToks[i].Type || syncToks[i].Literal != asyncToks[i].Literal {
+			t.Fatalf("token %d differs: sync=%+v async=%+v", i, syncToks[i], asyncToks[i])
+		}
+	}
+}
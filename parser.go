@@ -0,0 +1,540 @@
+package gps
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError describes a failure to parse a query, including the byte
+// offset of the token that triggered it.
+type ParseError struct {
+	Message string
+	Offset  int
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at offset %d: %s", e.Offset, e.Message)
+}
+
+// parser holds the state for a single top-down recursive-descent parse.
+type parser struct {
+	lex *PeekingLexer
+}
+
+// Parse parses a single SELECT statement (optionally UNION-ed with
+// further SELECTs) and returns its AST.
+func Parse(input string) (*SelectStmt, error) {
+	p := &parser{lex: NewPeekingLexer(NewLexer("parse", input))}
+	stmt, err := p.parseSelectStmt()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.lex.Peek(); tok.Type != EOF {
+		return nil, p.errorf(tok, "unexpected trailing %s", tok.String())
+	}
+	return stmt, nil
+}
+
+func (p *parser) errorf(tok Token, format string, args ...interface{}) error {
+	return &ParseError{Message: fmt.Sprintf(format, args...), Offset: tok.Pos.Offset}
+}
+
+// expect consumes the next token and checks its type.
+func (p *parser) expect(t TokenType) (Token, error) {
+	tok := p.lex.Next()
+	if tok.Type != t {
+		return tok, p.errorf(tok, "expected token %d, got %s", t, tok.String())
+	}
+	return tok, nil
+}
+
+// at reports whether the next token (without consuming it) has type t.
+func (p *parser) at(t TokenType) bool {
+	return p.lex.Peek().Type == t
+}
+
+func (p *parser) parseSelectStmt() (*SelectStmt, error) {
+	start, err := p.expect(Select)
+	if err != nil {
+		return nil, err
+	}
+	stmt := &SelectStmt{Offset: start.Pos.Offset}
+
+	if p.at(Distinct) {
+		p.lex.Next()
+		stmt.Distinct = true
+	}
+
+	items, err := p.parseSelectItemList()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Columns = items
+
+	if _, err := p.expect(From); err != nil {
+		return nil, err
+	}
+	from, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	stmt.From = from
+
+	for p.at(Join) || p.at(Left) || p.at(Right) || p.at(Inner) || p.at(Outer) {
+		join, err := p.parseJoinClause()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Joins = append(stmt.Joins, join)
+	}
+
+	if p.at(Where) {
+		p.lex.Next()
+		where, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Where = where
+	}
+
+	if p.at(Group) {
+		p.lex.Next()
+		if _, err := p.expect(By); err != nil {
+			return nil, err
+		}
+		groupBy, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		stmt.GroupBy = groupBy
+	}
+
+	if p.at(Having) {
+		p.lex.Next()
+		having, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Having = having
+	}
+
+	if p.at(Order) {
+		p.lex.Next()
+		if _, err := p.expect(By); err != nil {
+			return nil, err
+		}
+		orderBy, err := p.parseOrderItemList()
+		if err != nil {
+			return nil, err
+		}
+		stmt.OrderBy = orderBy
+	}
+
+	if p.at(Limit) {
+		tok := p.lex.Next()
+		count, err := p.expect(Number)
+		if err != nil {
+			return nil, err
+		}
+		n, perr := parseIntLiteral(count.Literal)
+		if perr != nil {
+			return nil, p.errorf(count, "invalid LIMIT count %q", count.Literal)
+		}
+		stmt.Limit = &LimitClause{Count: n, Offset: tok.Pos.Offset}
+	}
+
+	if p.at(Union) {
+		tok := p.lex.Next()
+		all := false
+		if p.at(All) {
+			p.lex.Next()
+			all = true
+		}
+		next, err := p.parseSelectStmt()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Union = &UnionClause{All: all, Select: next, Offset: tok.Pos.Offset}
+	}
+
+	return stmt, nil
+}
+
+func (p *parser) parseSelectItemList() ([]*SelectItem, error) {
+	var items []*SelectItem
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.at(Sep) {
+			p.lex.Next()
+			continue
+		}
+		break
+	}
+	return items, nil
+}
+
+func (p *parser) parseSelectItem() (*SelectItem, error) {
+	if p.at(Star) {
+		tok := p.lex.Next()
+		return &SelectItem{Star: true, Offset: tok.Pos.Offset}, nil
+	}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	item := &SelectItem{Expr: expr, Offset: expr.Pos()}
+	if p.at(As) {
+		p.lex.Next()
+		alias, err := p.expect(Ident)
+		if err != nil {
+			return nil, err
+		}
+		item.Alias = alias.Literal
+	}
+	return item, nil
+}
+
+func (p *parser) parseTableRef() (*TableRef, error) {
+	name, err := p.expect(Ident)
+	if err != nil {
+		return nil, err
+	}
+	ref := &TableRef{Name: name.Literal, Offset: name.Pos.Offset}
+	if p.at(As) {
+		p.lex.Next()
+		alias, err := p.expect(Ident)
+		if err != nil {
+			return nil, err
+		}
+		ref.Alias = alias.Literal
+	} else if p.at(Ident) {
+		alias := p.lex.Next()
+		ref.Alias = alias.Literal
+	}
+	return ref, nil
+}
+
+func (p *parser) parseJoinClause() (*JoinClause, error) {
+	start := p.lex.Peek()
+	kind := ""
+	switch start.Type {
+	case Left, Right, Inner, Outer:
+		p.lex.Next()
+		kind = strings.ToUpper(start.Literal)
+	}
+	if _, err := p.expect(Join); err != nil {
+		return nil, err
+	}
+	table, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	join := &JoinClause{Kind: kind, Table: table, Offset: start.Pos.Offset}
+	if p.at(On) {
+		p.lex.Next()
+		on, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		join.On = on
+	}
+	return join, nil
+}
+
+func (p *parser) parseOrderItemList() ([]*OrderItem, error) {
+	var items []*OrderItem
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		item := &OrderItem{Expr: expr, Offset: expr.Pos()}
+		if p.at(Asc) {
+			p.lex.Next()
+		} else if p.at(Desc) {
+			p.lex.Next()
+			item.Desc = true
+		}
+		items = append(items, item)
+		if p.at(Sep) {
+			p.lex.Next()
+			continue
+		}
+		break
+	}
+	return items, nil
+}
+
+func (p *parser) parseExprList() ([]Expr, error) {
+	var exprs []Expr
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if p.at(Sep) {
+			p.lex.Next()
+			continue
+		}
+		break
+	}
+	return exprs, nil
+}
+
+// Expression grammar, lowest to highest precedence:
+//
+//	expr       -> orExpr
+//	orExpr     -> andExpr (OR andExpr)*
+//	andExpr    -> notExpr (AND notExpr)*
+//	notExpr    -> NOT notExpr | predicate
+//	predicate  -> additive ( cmpOp additive
+//	            | [NOT] BETWEEN additive AND additive
+//	            | [NOT] LIKE additive
+//	            | IS [NOT] NULL
+//	            | [NOT] IN '(' exprList ')' )?
+//	additive   -> multiplicative ((+|-) multiplicative)*
+//	multiplicative -> primary ((*|/|%) primary)*
+//	primary    -> literal | '(' expr ')' | ident ('.' ident)* | ident '(' args ')'
+
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.at(Or) {
+		tok := p.lex.Next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: Or, Literal: "OR", Left: left, Right: right, Offset: tok.Pos.Offset}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.at(And) {
+		tok := p.lex.Next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: And, Literal: "AND", Left: left, Right: right, Offset: tok.Pos.Offset}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.at(Not) {
+		tok := p.lex.Next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: Not, Expr: expr, Offset: tok.Pos.Offset}, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *parser) parsePredicate() (Expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	not := false
+	if p.at(Not) {
+		p.lex.Next()
+		not = true
+		if !p.at(Between) && !p.at(Like) && !p.at(In) {
+			return nil, p.errorf(p.lex.Peek(), "expected BETWEEN, LIKE or IN after NOT")
+		}
+	}
+
+	switch {
+	case p.at(Op):
+		tok := p.lex.Next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpr{Op: Op, Literal: tok.Literal, Left: left, Right: right, Offset: tok.Pos.Offset}, nil
+
+	case p.at(Between):
+		tok := p.lex.Next()
+		low, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(And); err != nil {
+			return nil, err
+		}
+		high, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &BetweenExpr{Expr: left, Not: not, Low: low, High: high, Offset: tok.Pos.Offset}, nil
+
+	case p.at(Like):
+		tok := p.lex.Next()
+		pattern, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &LikeExpr{Expr: left, Not: not, Pattern: pattern, Offset: tok.Pos.Offset}, nil
+
+	case p.at(In):
+		tok := p.lex.Next()
+		if _, err := p.expect(Paren); err != nil {
+			return nil, err
+		}
+		list, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(Paren); err != nil {
+			return nil, err
+		}
+		return &InExpr{Expr: left, Not: not, List: list, Offset: tok.Pos.Offset}, nil
+
+	case p.at(Is):
+		tok := p.lex.Next()
+		isNot := false
+		if p.at(Not) {
+			p.lex.Next()
+			isNot = true
+		}
+		if _, err := p.expect(Null); err != nil {
+			return nil, err
+		}
+		return &IsNullExpr{Expr: left, Not: isNot, Offset: tok.Pos.Offset}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (Expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.at(Op) && (p.lex.Peek().Literal == "+" || p.lex.Peek().Literal == "-") {
+		tok := p.lex.Next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: Op, Literal: tok.Literal, Left: left, Right: right, Offset: tok.Pos.Offset}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (Expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for (p.at(Op) && p.lex.Peek().Literal == "/") || p.at(Star) || p.at(Mod) {
+		tok := p.lex.Next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: Op, Literal: tok.Literal, Left: left, Right: right, Offset: tok.Pos.Offset}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.lex.Peek()
+	switch tok.Type {
+	case Number, String:
+		p.lex.Next()
+		return &LiteralExpr{Type: tok.Type, Value: tok.Literal, Offset: tok.Pos.Offset}, nil
+	case True, False, Null:
+		p.lex.Next()
+		return &LiteralExpr{Type: tok.Type, Value: tok.Literal, Offset: tok.Pos.Offset}, nil
+	case Paren:
+		if tok.Literal != "(" {
+			return nil, p.errorf(tok, "unexpected %s", tok.String())
+		}
+		p.lex.Next()
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(Paren); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case Ident:
+		p.lex.Next()
+		return p.parseIdentOrCall(tok)
+	}
+	return nil, p.errorf(tok, "unexpected %s in expression", tok.String())
+}
+
+// parseIdentOrCall handles the three shapes an identifier can start:
+// a bare column (`b`), a qualified column (`a.b`, `schema.table.col`,
+// ...), and a function call (`f(args...)`).
+func (p *parser) parseIdentOrCall(first Token) (Expr, error) {
+	if p.at(Paren) && p.lex.Peek().Literal == "(" {
+		p.lex.Next()
+		var args []Expr
+		if !(p.at(Paren) && p.lex.Peek().Literal == ")") {
+			list, err := p.parseExprList()
+			if err != nil {
+				return nil, err
+			}
+			args = list
+		}
+		if _, err := p.expect(Paren); err != nil {
+			return nil, err
+		}
+		return &FuncCall{Name: first.Literal, Args: args, Offset: first.Pos.Offset}, nil
+	}
+
+	if p.at(Dot) {
+		parts := []string{first.Literal}
+		for p.at(Dot) {
+			p.lex.Next()
+			name, err := p.expect(Ident)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, name.Literal)
+		}
+		last := len(parts) - 1
+		return &ColumnRef{Qualifiers: parts[:last], Name: parts[last], Offset: first.Pos.Offset}, nil
+	}
+
+	return &ColumnRef{Name: first.Literal, Offset: first.Pos.Offset}, nil
+}
+
+// parseIntLiteral converts a lexed Number literal (e.g. "10") to an int.
+func parseIntLiteral(s string) (int, error) {
+	n := 0
+	if s == "" {
+		return 0, fmt.Errorf("empty number")
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("not an integer: %q", s)
+		}
+		n = n*10 + int(r-'0')
+	}
+	return n, nil
+}
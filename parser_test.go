@@ -0,0 +1,83 @@
+package gps
+
+import "testing"
+
+func TestParseSimpleSelect(t *testing.T) {
+	stmt, err := Parse("select a, b from orders where a = 10 and b = 'x'")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(stmt.Columns) != 2 {
+		t.Fatalf("expected 2 columns, got %d", len(stmt.Columns))
+	}
+	if stmt.From.Name != "orders" {
+		t.Fatalf("expected from orders, got %q", stmt.From.Name)
+	}
+	if _, ok := stmt.Where.(*BinaryExpr); !ok {
+		t.Fatalf("expected top-level WHERE to be a BinaryExpr, got %T", stmt.Where)
+	}
+}
+
+func TestParseJoinGroupByOrderByLimit(t *testing.T) {
+	stmt, err := Parse("select o.id, count(o.id) from orders o " +
+		"left join customers c on o.cid = c.id " +
+		"where o.amount between 1 and 100 " +
+		"group by o.id having count(o.id) > 1 " +
+		"order by o.id desc limit 10")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(stmt.Joins) != 1 || stmt.Joins[0].Kind != "LEFT" {
+		t.Fatalf("expected one LEFT join, got %+v", stmt.Joins)
+	}
+	if _, ok := stmt.Where.(*BetweenExpr); !ok {
+		t.Fatalf("expected WHERE to be a BetweenExpr, got %T", stmt.Where)
+	}
+	if stmt.Limit == nil || stmt.Limit.Count != 10 {
+		t.Fatalf("expected limit 10, got %+v", stmt.Limit)
+	}
+	if len(stmt.OrderBy) != 1 || !stmt.OrderBy[0].Desc {
+		t.Fatalf("expected one descending order item, got %+v", stmt.OrderBy)
+	}
+}
+
+func TestParseQualifiedColumnRef(t *testing.T) {
+	stmt, err := Parse("select schema.table.column from t")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	col, ok := stmt.Columns[0].Expr.(*ColumnRef)
+	if !ok {
+		t.Fatalf("expected a ColumnRef, got %T", stmt.Columns[0].Expr)
+	}
+	if len(col.Qualifiers) != 2 || col.Qualifiers[0] != "schema" || col.Qualifiers[1] != "table" || col.Name != "column" {
+		t.Fatalf("expected Qualifiers [schema table] Name column, got %+v", col)
+	}
+}
+
+func TestParseUnionAndIn(t *testing.T) {
+	stmt, err := Parse("select a from t where a in (1, 2, 3) union all select a from t2")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, ok := stmt.Where.(*InExpr); !ok {
+		t.Fatalf("expected WHERE to be an InExpr, got %T", stmt.Where)
+	}
+	if stmt.Union == nil || !stmt.Union.All || stmt.Union.Select.From.Name != "t2" {
+		t.Fatalf("expected UNION ALL onto t2, got %+v", stmt.Union)
+	}
+}
+
+func TestParseErrorReportsOffset(t *testing.T) {
+	_, err := Parse("select from t")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %T", err)
+	}
+	if perr.Offset != 7 {
+		t.Fatalf("expected error offset 7, got %d", perr.Offset)
+	}
+}
@@ -0,0 +1,32 @@
+package gps
+
+// PeekingLexer wraps a Lexer to give the parser one token of lookahead:
+// it is the only caller of l.Next(), and buffers at most one token at a
+// time.
+type PeekingLexer struct {
+	lex    *Lexer
+	tok    Token
+	peeked bool
+}
+
+// NewPeekingLexer wraps l for use by the parser.
+func NewPeekingLexer(l *Lexer) *PeekingLexer {
+	return &PeekingLexer{lex: l}
+}
+
+// Peek returns the next token without consuming it. Calling Peek
+// repeatedly without an intervening Next returns the same token.
+func (p *PeekingLexer) Peek() Token {
+	if !p.peeked {
+		p.tok, _ = p.lex.Next()
+		p.peeked = true
+	}
+	return p.tok
+}
+
+// Next consumes and returns the next token.
+func (p *PeekingLexer) Next() Token {
+	t := p.Peek()
+	p.peeked = false
+	return t
+}
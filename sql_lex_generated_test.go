@@ -0,0 +1,57 @@
+package gps
+
+import "testing"
+
+func TestGeneratedLexerMatchesHandWritten(t *testing.T) {
+	input := "select a, _foo, b from orders where a = 10 and b = 'it''s a \\'test\\'' -- trailing\n" +
+		"/* block */ order by a desc limit 10"
+
+	hand := NewLexer("hand", input)
+	handToks, err := ConsumeAll(hand)
+	if err != nil {
+		t.Fatalf("ConsumeAll(hand): %v", err)
+	}
+
+	gen := NewGeneratedLexer(input)
+	var genToks []GeneratedToken
+	for {
+		tok, err := gen.Next()
+		genToks = append(genToks, tok)
+		if tok.Type == EOF {
+			break
+		}
+		if err != nil && tok.Type != Error {
+			t.Fatalf("GeneratedLexer.Next: %v", err)
+		}
+	}
+
+	if len(handToks) != len(genToks) {
+		t.Fatalf("hand produced %d tokens, generated produced %d", len(handToks), len(genToks))
+	}
+	for i := range handToks {
+		if handToks[i].Type != genToks[i].Type {
+			t.Fatalf("token %d differs: hand=%+v generated=%+v", i, handToks[i], genToks[i])
+		}
+		// Error tokens carry a formatted message that embeds the
+		// lexer's own name/wording, not just the offending input, so
+		// only their Type (not their Literal) is comparable here.
+		if handToks[i].Type != Error && handToks[i].Literal != string(genToks[i].Literal) {
+			t.Fatalf("token %d differs: hand=%+v generated=%+v", i, handToks[i], genToks[i])
+		}
+	}
+}
+
+func TestGeneratedLexerUnterminatedStringDoesNotHang(t *testing.T) {
+	g := NewGeneratedLexer("select 'abc")
+	var toks []GeneratedToken
+	for {
+		tok, _ := g.Next()
+		toks = append(toks, tok)
+		if tok.Type == EOF {
+			break
+		}
+	}
+	if len(toks) != 3 || toks[1].Type != Error || toks[2].Type != EOF {
+		t.Fatalf("expected [SELECT, Error, EOF], got %v", toks)
+	}
+}
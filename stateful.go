@@ -0,0 +1,237 @@
+package gps
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Rule is one entry in a state's ordered match list: if Pattern matches
+// at the cursor, Type (or, for Ident, a keyword lookup) is emitted and
+// PushState/PopState update the mode stack.
+//
+// Patterns are tried in order and the first match wins (unlike the
+// hand-written lexer's byte-by-byte switch, there's no backtracking
+// once a rule matches).
+type Rule struct {
+	Pattern *regexp.Regexp
+	Type    TokenType
+	// Skip discards the match instead of emitting a token (whitespace,
+	// comments) and starts the next lexeme after it.
+	Skip bool
+	// Continue consumes the match without emitting a token and without
+	// starting a new lexeme, so a later rule's emit (typically a
+	// PopState rule) covers this match too. This is how a multi-char
+	// literal like a quoted string is accumulated across several rule
+	// matches but still produced as a single Token.
+	Continue bool
+	// PushState, if non-empty, enters that state after this match.
+	PushState string
+	// PopState leaves the current state after this match.
+	PopState bool
+}
+
+// Rules maps a state name to its ordered rule list. "Root" is the
+// state a stateful Lexer starts in.
+type Rules map[string][]Rule
+
+// rule builds a Rule whose Pattern is anchored at the start of the
+// remaining input (^...), which is how the stateful lexer always
+// matches: at the cursor, never searching ahead.
+func rule(pattern string, t TokenType) Rule {
+	return Rule{Pattern: regexp.MustCompile("^(?:" + pattern + ")"), Type: t}
+}
+
+// DefaultRules is the built-in "Root"/"String"/"Backtick"/"DblQuoted"/
+// "Comment" ruleset: it accepts the same language as the hand-written
+// lexer in lex.go, expressed as data instead of Go control flow.
+// Callers extend the SQL dialect by copying this and adding or
+// overriding rules, rather than editing the core state functions.
+func DefaultRules() Rules {
+	return Rules{
+		"Root": {
+			withSkip(rule(`[ \t\r\n]+`, Error)),
+			withSkip(rule(`--[^\n]*`, Error)), // line comment: consumed whole, no push needed.
+			withContinue(withPush(rule(`'`, String), "String")),
+			withContinue(withPush(rule("`", Literal), "Backtick")),
+			withContinue(withPush(rule(`"`, DblQuotedIdent), "DblQuoted")),
+			withSkip(withPush(rule(`/\*`, Error), "Comment")),
+			// Multi-char operators must precede the single-char rules they
+			// share a prefix with, since the first matching rule wins.
+			rule(`<=`, Op),
+			rule(`>=`, Op),
+			rule(`<>`, Op),
+			rule(`!=`, Op),
+			rule(`<<`, ShiftL),
+			rule(`>>`, ShiftR),
+			rule(`\|\|`, Concat),
+			rule(`[=+\-/]`, Op),
+			rule(`%`, Mod),
+			rule(`&`, BitAnd),
+			rule(`\^`, BitXor),
+			rule(`\|`, BitOr),
+			rule(`<`, Op),
+			rule(`>`, Op),
+			rule(`,`, Sep),
+			rule(`\.`, Dot),
+			rule(`[()]`, Paren),
+			rule(`[0-9]+(\.[0-9]*)?`, Number),
+			rule(`[A-Za-z][A-Za-z0-9_]*`, Ident), // Type is overridden by keyword lookup at match time.
+		},
+		"String": {
+			withContinue(rule(`\\.`, String)),  // backslash escape: consume the escaped char, keep accumulating.
+			withContinue(rule(`''`, String)),   // doubled single-quote: an escaped quote, keep accumulating.
+			withPop(rule(`'`, String)),
+			withContinue(rule(`[^'\\]+`, String)),
+		},
+		"Backtick": {
+			withContinue(rule("``", Literal)), // doubled backtick: an escaped backtick, keep accumulating.
+			withPop(rule("`", Literal)),
+			withContinue(rule("[^`]+", Literal)),
+		},
+		"DblQuoted": {
+			withContinue(rule(`""`, DblQuotedIdent)), // doubled double-quote: an escaped quote, keep accumulating.
+			withPop(rule(`"`, DblQuotedIdent)),
+			withContinue(rule(`[^"]+`, DblQuotedIdent)),
+		},
+		"Comment": {
+			withSkipPop(rule(`\*/`, Error)),
+			withContinue(rule(`[^*]+|\*`, Error)),
+		},
+	}
+}
+
+func withSkip(r Rule) Rule {
+	r.Skip = true
+	return r
+}
+
+func withSkipPop(r Rule) Rule {
+	r.Skip = true
+	r.PopState = true
+	return r
+}
+
+func withContinue(r Rule) Rule {
+	r.Continue = true
+	return r
+}
+
+func withPush(r Rule, state string) Rule {
+	r.PushState = state
+	return r
+}
+
+func withPop(r Rule) Rule {
+	r.PopState = true
+	return r
+}
+
+// NewStatefulLexer creates a Lexer driven by rules instead of
+// hand-written state functions. It produces the same Token stream as
+// NewLexer (same Next/ConsumeAll/Errors API), but the grammar it
+// accepts is data: callers can support new syntax (PostgreSQL
+// dollar-quoted strings, MySQL '#' comments, "double-quoted"
+// identifiers, ...) by adding rules instead of editing lex.go.
+// IncludeComments has no effect on it: comments are emitted or skipped
+// only as its rules say, and DefaultRules skips them.
+//
+// If rules is nil, DefaultRules() is used.
+func NewStatefulLexer(rules Rules, input string) (*Lexer, error) {
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	if _, ok := rules["Root"]; !ok {
+		return nil, fmt.Errorf("gps: stateful lexer rules must define a %q state", "Root")
+	}
+
+	l := &Lexer{
+		input:     input,
+		line:      1,
+		col:       1,
+		startLine: 1,
+		startCol:  1,
+		rules:     rules,
+		modeStack: []string{"Root"},
+	}
+	l.state = statefulStep
+	return l, nil
+}
+
+// statefulStep is the Lexer.state implementation used by
+// NewStatefulLexer: it's the rule-driven analogue of expectAny.
+func statefulStep(l *Lexer) State {
+	mode := l.modeStack[len(l.modeStack)-1]
+	if l.pos >= len(l.input) {
+		if mode != "Root" {
+			// Input ran out inside a quoted literal or block comment.
+			// Like the hand-written lexer, report it and leave Next's
+			// fallback to produce the final EOF.
+			l.errorf("unterminated input in state %q", mode)
+			return nil
+		}
+		l.emit(EOF)
+		return nil
+	}
+
+	for _, r := range l.rules[mode] {
+		loc := r.Pattern.FindStringIndex(l.input[l.pos:])
+		if loc == nil || loc[0] != 0 {
+			continue
+		}
+		l.advance(loc[1])
+
+		if r.PopState {
+			l.modeStack = l.modeStack[:len(l.modeStack)-1]
+		}
+		if r.PushState != "" {
+			l.modeStack = append(l.modeStack, r.PushState)
+		}
+
+		if r.Skip {
+			l.ignore()
+			return statefulStep
+		}
+		if r.Continue {
+			return statefulStep
+		}
+
+		t := r.Type
+		if t == Ident {
+			if kw, ok := lookupKeyword(l.cache()); ok {
+				t = kw
+			}
+		}
+		l.emit(t)
+		return statefulStep
+	}
+
+	l.errorf("no rule matches input in state %q: %q", mode, previewRune(l.input[l.pos:]))
+	return l.recover()
+}
+
+// advance moves the cursor n bytes forward, keeping line/col in sync
+// the same way next() does one rune at a time.
+func (l *Lexer) advance(n int) {
+	end := l.pos + n
+	for l.pos < end {
+		r, width := utf8.DecodeRuneInString(l.input[l.pos:])
+		l.pos += width
+		if r == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+	}
+}
+
+// previewRune returns the first rune of s as a string, for error
+// messages; "" if s is empty.
+func previewRune(s string) string {
+	_, width := utf8.DecodeRuneInString(s)
+	if width == 0 {
+		return ""
+	}
+	return s[:width]
+}
@@ -0,0 +1,85 @@
+package gps
+
+import "testing"
+
+func TestStatefulLexerMatchesDefault(t *testing.T) {
+	input := "select a, _foo, b from orders where a = 10 and b = 'x' and c << 2 >> 1 " +
+		"and d & 1 | 2 ^ 3 % 4 and e || f and g = `lit``eral` and h = \"q\"\"uoted\""
+
+	hand := NewLexer("hand", input)
+	handToks, err := ConsumeAll(hand)
+	if err != nil {
+		t.Fatalf("ConsumeAll(hand): %v", err)
+	}
+
+	stateful, err := NewStatefulLexer(nil, input)
+	if err != nil {
+		t.Fatalf("NewStatefulLexer: %v", err)
+	}
+	statefulToks, err := ConsumeAll(stateful)
+	if err != nil {
+		t.Fatalf("ConsumeAll(stateful): %v", err)
+	}
+
+	if len(handToks) != len(statefulToks) {
+		t.Fatalf("hand produced %d tokens, stateful produced %d", len(handToks), len(statefulToks))
+	}
+	for i := range handToks {
+		if handToks[i].Type != statefulToks[i].Type {
+			t.Fatalf("token %d differs: hand=%+v stateful=%+v", i, handToks[i], statefulToks[i])
+		}
+		// Error tokens carry a formatted message that embeds the
+		// lexer's own wording, not just the offending input, so only
+		// their Type (not their Literal) is comparable here.
+		if handToks[i].Type != Error && handToks[i].Literal != statefulToks[i].Literal {
+			t.Fatalf("token %d differs: hand=%+v stateful=%+v", i, handToks[i], statefulToks[i])
+		}
+	}
+}
+
+func TestStatefulLexerAccumulatesQuotedLiterals(t *testing.T) {
+	l, err := NewStatefulLexer(nil, "select 'it''s a test' from t")
+	if err != nil {
+		t.Fatalf("NewStatefulLexer: %v", err)
+	}
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+
+	var str *Token
+	for i := range toks {
+		if toks[i].Type == String {
+			str = &toks[i]
+			break
+		}
+	}
+	if str == nil || str.Literal != "'it''s a test'" {
+		t.Fatalf("expected one String token spanning the whole literal, got %+v", str)
+	}
+}
+
+func TestStatefulLexerExtendsDialectWithHashComment(t *testing.T) {
+	rules := DefaultRules()
+	rules["Root"] = append([]Rule{withSkip(rule(`#[^\n]*`, Error))}, rules["Root"]...)
+
+	l, err := NewStatefulLexer(rules, "select a # trailing comment\nfrom t")
+	if err != nil {
+		t.Fatalf("NewStatefulLexer: %v", err)
+	}
+	toks, err := ConsumeAll(l)
+	if err != nil {
+		t.Fatalf("ConsumeAll: %v", err)
+	}
+	for _, tok := range toks {
+		if tok.Type == Error {
+			t.Fatalf("expected the # comment to be skipped, got an Error token: %+v", tok)
+		}
+	}
+}
+
+func TestNewStatefulLexerRejectsRulesWithoutRoot(t *testing.T) {
+	if _, err := NewStatefulLexer(Rules{"Other": nil}, "select 1"); err == nil {
+		t.Fatal("expected an error for rules missing a Root state")
+	}
+}